@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,9 +15,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/alecthomas/kong"
-	"github.com/sturaipo/glucose-exporter/api/librelink"
 	"github.com/sturaipo/glucose-exporter/collector"
+	exporterconfig "github.com/sturaipo/glucose-exporter/config"
+	"github.com/sturaipo/glucose-exporter/provider"
+	"github.com/sturaipo/glucose-exporter/provider/librelink"
+	"github.com/sturaipo/glucose-exporter/provider/multiplexer"
+	"github.com/sturaipo/glucose-exporter/provider/nightscout"
+	"github.com/sturaipo/glucose-exporter/pusher"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type CliCredentials struct {
@@ -54,29 +61,123 @@ type Config struct {
 
 	Creds CliCredentials `kong:"embed,prefix='credentials.',help='LibrelLink credentials (optional)'"`
 
+	TokenStorePath string `kong:"help='Path to a file for persisting the LibreLink auth ticket across restarts (kept in memory if unset)',env='TOKEN_STORE_PATH'"`
+
+	ConfigFile string `kong:"help='Path to a YAML/JSON config file; overrides --username, --password, --bind and --log.level, and enables SIGHUP hot-reload',env='CONFIG_FILE'"`
+
+	HTTP struct {
+		Timeout     time.Duration `kong:"help='Per-attempt timeout for LibreLink API requests',env='HTTP_TIMEOUT',default='10s'"`
+		MaxRetries  int           `kong:"help='Max retries for a failed LibreLink API request',env='HTTP_MAX_RETRIES',default='3'"`
+		BackoffBase time.Duration `kong:"help='Base delay for retry backoff',env='HTTP_BACKOFF_BASE',default='500ms'"`
+		BackoffMax  time.Duration `kong:"help='Max delay for retry backoff',env='HTTP_BACKOFF_MAX',default='10s'"`
+	} `kong:"embed,prefix='http.',help='LibreLink HTTP client options'"`
+
 	Log struct {
 		Level  string `kong:"help='Log level',env='LOG_LEVEL',default='info',enum='debug,info'"`
 		Format string `kong:"help='Log format',env='LOG_FORMAT',default='console',enum='console,json'"`
 	} `kong:"embed,prefix='log.',help='Logging options'"`
+
+	Mode struct {
+		Scrape bool `kong:"help='Serve /metrics and /glucose for scraping',env='MODE_SCRAPE',default='true'"`
+		Push   bool `kong:"help='Push samples to configured remote_write endpoints',env='MODE_PUSH'"`
+	} `kong:"embed,prefix='mode.',help='Exporter operating mode'"`
+
+	Push struct {
+		Endpoints   []string      `kong:"help='Prometheus remote_write endpoint URLs',env='PUSH_ENDPOINTS'"`
+		BearerToken string        `kong:"help='Bearer token sent to remote_write endpoints',env='PUSH_BEARER_TOKEN'"`
+		BasicUser   string        `kong:"help='Basic auth username sent to remote_write endpoints',env='PUSH_BASIC_USER'"`
+		BasicPass   string        `kong:"help='Basic auth password sent to remote_write endpoints',env='PUSH_BASIC_PASS'"`
+		Interval    time.Duration `kong:"help='Push interval',env='PUSH_INTERVAL',default='1m'"`
+	} `kong:"embed,prefix='push.',help='Remote-write push options'"`
+
+	Admin struct {
+		Bind string `kong:"help='Bind address for the admin endpoints (/-/log/level); served on --bind if unset',env='ADMIN_BIND'"`
+	} `kong:"embed,prefix='admin.',help='Admin endpoint options'"`
+
+	Nightscout struct {
+		URL         string `kong:"help='Nightscout base URL; set to enable the Nightscout provider alongside LibreLink',env='NIGHTSCOUT_URL'"`
+		APISecret   string `kong:"help='Nightscout API-SECRET header value',env='NIGHTSCOUT_API_SECRET'"`
+		PatientID   string `kong:"help='Patient id to report Nightscout readings under',env='NIGHTSCOUT_PATIENT_ID',default='nightscout'"`
+		PatientName string `kong:"help='Patient name to report Nightscout readings under',env='NIGHTSCOUT_PATIENT_NAME',default='Nightscout'"`
+	} `kong:"embed,prefix='nightscout.',help='Nightscout provider options'"`
 }
 
-func configureLogger(cfg Config) (*zap.Logger, error) {
+func (c Config) pushEndpoints() []pusher.Endpoint {
+	endpoints := make([]pusher.Endpoint, 0, len(c.Push.Endpoints))
+	for _, url := range c.Push.Endpoints {
+		endpoints = append(endpoints, pusher.Endpoint{
+			URL:         url,
+			BearerToken: c.Push.BearerToken,
+			BasicUser:   c.Push.BasicUser,
+			BasicPass:   c.Push.BasicPass,
+		})
+	}
+	return endpoints
+}
+
+func zapLevelFromString(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info":
+		return zap.InfoLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+// configureLogger builds the process logger and returns its AtomicLevel
+// so a config reload can change the level in place without rebuilding
+// the logger.
+func configureLogger(cfg Config) (*zap.Logger, zap.AtomicLevel, error) {
 	zapConfig := zap.NewProductionConfig()
 
 	if cfg.Log.Format == "console" {
 		zapConfig.Encoding = "console"
 	}
 
-	switch cfg.Log.Level {
-	case "debug":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	default:
-		return nil, fmt.Errorf("unknown log level: %s", cfg.Log.Level)
+	level, err := zapLevelFromString(cfg.Log.Level)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
 	}
+	zapConfig.Level = zap.NewAtomicLevelAt(level)
 
-	return zapConfig.Build()
+	logger, err := zapConfig.Build()
+	return logger, zapConfig.Level, err
+}
+
+// reloadConfig re-reads cfg.ConfigFile on SIGHUP and applies the log
+// level and LibreLink credentials in place, without dropping the HTTP
+// listener. A changed bind address cannot take effect without a restart,
+// so it is only logged. A no-op if cfg.ConfigFile was never set.
+func reloadConfig(cfg Config, cfgHandler *exporterconfig.Handler, client *librelink.LibreLinkClient, atomicLevel zap.AtomicLevel, logger *zap.Logger) {
+	if cfg.ConfigFile == "" {
+		logger.Warn("received SIGHUP but no --config-file is set, ignoring")
+		return
+	}
+
+	fingerprint := cfgHandler.Fingerprint()
+	if err := exporterconfig.ReloadFile(cfgHandler, cfg.ConfigFile, fingerprint); err != nil {
+		logger.Error("failed to reload config", zap.Error(err))
+		return
+	}
+
+	reloaded := cfgHandler.Current()
+
+	if reloaded.Bind != cfg.Bind {
+		logger.Warn("bind address changed in config but requires a restart to take effect",
+			zap.String("current", cfg.Bind), zap.String("configured", reloaded.Bind))
+	}
+
+	if level, err := zapLevelFromString(reloaded.LogLevel); err != nil {
+		logger.Error("invalid log level in reloaded config, keeping current level", zap.String("level", reloaded.LogLevel))
+	} else {
+		atomicLevel.SetLevel(level)
+	}
+
+	client.UpdateCredentials(reloaded.Username, reloaded.Password)
+
+	logger.Info("config reloaded", zap.String("fingerprint", cfgHandler.Fingerprint()))
 }
 
 func main() {
@@ -84,7 +185,26 @@ func main() {
 	config := Config{}
 	kong.Parse(&config)
 
-	logger, err := configureLogger(config)
+	cfgHandler := exporterconfig.NewHandler(exporterconfig.ExporterConfig{
+		Bind:     config.Bind,
+		Username: config.Username,
+		Password: config.Password,
+		LogLevel: config.Log.Level,
+	})
+
+	if config.ConfigFile != "" {
+		if err := exporterconfig.LoadFile(cfgHandler, config.ConfigFile); err != nil {
+			panic(err)
+		}
+
+		loaded := cfgHandler.Current()
+		config.Bind = loaded.Bind
+		config.Username = loaded.Username
+		config.Password = loaded.Password
+		config.Log.Level = loaded.LogLevel
+	}
+
+	logger, atomicLevel, err := configureLogger(config)
 	if err != nil {
 		panic(err)
 	}
@@ -99,6 +219,16 @@ func main() {
 		options = append(options, librelink.WithExpiringCredentials(config.Creds.UserId, config.Creds.Token, config.Creds.Expiry))
 	}
 
+	if config.TokenStorePath != "" {
+		options = append(options, librelink.WithTokenStore(librelink.NewFileTokenStore(config.TokenStorePath)))
+	}
+
+	options = append(options,
+		librelink.WithHTTPTimeout(config.HTTP.Timeout),
+		librelink.WithMaxRetries(config.HTTP.MaxRetries),
+		librelink.WithRetryBackoff(config.HTTP.BackoffBase, config.HTTP.BackoffMax),
+	)
+
 	// Initialize your LibreLink client here
 	client := librelink.NewLibreLinkClient(
 		config.Username,
@@ -118,41 +248,100 @@ func main() {
 		),
 	)
 
-	collector := collector.NewGlucoseCollector(client)
+	providers := []provider.CGMProvider{librelink.NewProvider(client)}
+
+	if config.Nightscout.URL != "" {
+		nightscoutClient, err := nightscout.NewClient(
+			config.Nightscout.URL,
+			config.Nightscout.APISecret,
+			nightscout.WithPatient(config.Nightscout.PatientID, config.Nightscout.PatientName),
+		)
+		if err != nil {
+			logger.Fatal("failed to configure nightscout provider", zap.Error(err))
+		}
+		providers = append(providers, nightscoutClient)
+	}
+
+	var cgmProvider provider.CGMProvider = providers[0]
+	if len(providers) > 1 {
+		cgmProvider = multiplexer.New(providers...)
+	}
+
+	collector := collector.NewGlucoseCollector(cgmProvider, collector.WithLogger(logger))
 
 	glucoseRegistry := prometheus.NewPedanticRegistry()
 	glucoseRegistry.MustRegister(collector)
 
 	handler := http.NewServeMux()
-	handler.Handle(
-		"/glucose",
-		promhttp.InstrumentMetricHandler(
-			prometheus.DefaultRegisterer,
-			promhttp.HandlerFor(glucoseRegistry, promhttp.HandlerOpts{Registry: glucoseRegistry}),
-		),
-	)
-	handler.Handle(
-		"/metrics",
-		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}),
-	)
+	if config.Mode.Scrape {
+		handler.Handle(
+			"/glucose",
+			promhttp.InstrumentMetricHandler(
+				prometheus.DefaultRegisterer,
+				promhttp.HandlerFor(glucoseRegistry, promhttp.HandlerOpts{Registry: glucoseRegistry}),
+			),
+		)
+		handler.Handle(
+			"/metrics",
+			promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}),
+		)
+	}
+
+	if config.Admin.Bind == "" {
+		handler.Handle("/-/log/level", atomicLevel)
+	} else {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/-/log/level", atomicLevel)
+		adminServer := &http.Server{
+			Addr:    config.Admin.Bind,
+			Handler: adminMux,
+		}
+		go func() {
+			logger.Info("Starting admin server", zap.String("address", config.Admin.Bind))
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Admin server failed", zap.Error(err))
+			}
+		}()
+	}
 
 	server := &http.Server{
 		Addr:    config.Bind,
 		Handler: handler,
 	}
 
-	go func() {
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, os.Interrupt)
+	pushCtx, cancelPush := context.WithCancel(context.Background())
 
-		sig := <-sigs
-		logger.Info("Shutting down server", zap.String("signal", sig.String()))
+	if config.Mode.Push {
+		endpoints := config.pushEndpoints()
+		if len(endpoints) == 0 {
+			logger.Fatal("push mode enabled but no --push.endpoints configured")
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if err := server.Shutdown(ctx); err != nil {
-			logger.Fatal("Server shutdown failed", zap.Error(err))
+		logger.Info("Starting remote_write pusher", zap.Int("endpoints", len(endpoints)), zap.Duration("interval", config.Push.Interval))
+		p := pusher.NewPusher(client, endpoints, pusher.WithLogger(logger))
+		go p.Run(pushCtx, config.Push.Interval)
+	}
+
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGHUP)
+
+		for sig := range sigs {
+			if sig == syscall.SIGHUP {
+				reloadConfig(config, cfgHandler, client, atomicLevel, logger)
+				continue
+			}
+
+			logger.Info("Shutting down server", zap.String("signal", sig.String()))
+			cancelPush()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := server.Shutdown(ctx); err != nil {
+				logger.Fatal("Server shutdown failed", zap.Error(err))
+			}
+			cancel()
+			return
 		}
-		defer cancel()
 	}()
 
 	logger.Info("Starting server", zap.String("address", config.Bind))
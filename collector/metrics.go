@@ -2,42 +2,77 @@ package collector
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/sturaipo/glucose-exporter/api/librelink"
+	"github.com/sturaipo/glucose-exporter/provider"
+	"go.uber.org/zap"
 )
 
+// historyWindow bounds how far back Collect asks providers for historic
+// readings on each scrape.
+const historyWindow = 24 * time.Hour
+
 type GlucoseCollector struct {
-	client *librelink.LibreLinkClient
+	provider provider.CGMProvider
+	logger   *zap.Logger
+
+	glucoseLevelDesc     *prometheus.Desc
+	glucoseLevelMgdlDesc *prometheus.Desc
+	trendDesc            *prometheus.Desc
+	historicDataDesc     *prometheus.Desc
+	historicDataMgdlDesc *prometheus.Desc
+}
 
-	glucoseLevelDesc *prometheus.Desc
-	trendDesc        *prometheus.Desc
-	historicDataDesc *prometheus.Desc
+func WithLogger(logger *zap.Logger) func(*GlucoseCollector) {
+	return func(gc *GlucoseCollector) {
+		gc.logger = logger
+	}
 }
 
-func NewGlucoseCollector(client *librelink.LibreLinkClient) *GlucoseCollector {
-	return &GlucoseCollector{
-		client: client,
+func NewGlucoseCollector(p provider.CGMProvider, options ...func(*GlucoseCollector)) *GlucoseCollector {
+	labels := []string{"patient_id", "patient_name", "provider"}
+
+	gc := &GlucoseCollector{
+		provider: p,
+		logger:   zap.NewNop(),
 		glucoseLevelDesc: prometheus.NewDesc(
-			prometheus.BuildFQName("glucose", "librelink", "level_mmoll"),
+			prometheus.BuildFQName("glucose", "", "level_mmoll"),
 			"Current glucose level in mmmol/L",
-			[]string{"patient_id", "patient_name"},
+			labels,
+			nil,
+		),
+		glucoseLevelMgdlDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("glucose", "", "level_mgdl"),
+			"Current glucose level in mg/dL",
+			labels,
 			nil,
 		),
 		trendDesc: prometheus.NewDesc(
-			prometheus.BuildFQName("glucose", "librelink", "trend"),
+			prometheus.BuildFQName("glucose", "", "trend"),
 			"Current glucose trend",
-			[]string{"patient_id", "patient_name"},
+			labels,
 			nil,
 		),
 		historicDataDesc: prometheus.NewDesc(
-			prometheus.BuildFQName("glucose", "librelink", "historic_level"),
+			prometheus.BuildFQName("glucose", "", "historic_level"),
 			"Historic glucose data",
-			[]string{"patient_id", "patient_name"},
+			labels,
+			nil,
+		),
+		historicDataMgdlDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("glucose", "", "historic_level_mgdl"),
+			"Historic glucose data in mg/dL",
+			labels,
 			nil,
 		),
 	}
+
+	for _, option := range options {
+		option(gc)
+	}
+
+	return gc
 }
 
 func (gc GlucoseCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -47,67 +82,91 @@ func (gc GlucoseCollector) Describe(ch chan<- *prometheus.Desc) {
 func (gc GlucoseCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx := context.Background()
 
-	if !gc.client.IsAuthenticated() {
-		if err := gc.client.Authenticate(ctx); err != nil {
-			return
-		}
+	// A multiplexed provider returns a joined error the moment any single
+	// underlying provider fails, but still authenticates and lists
+	// patients for the rest. Log and keep going rather than blanking out
+	// an entire scrape over one unhealthy provider.
+	if err := gc.provider.Authenticate(ctx); err != nil {
+		gc.logger.Warn("authenticate failed for one or more providers", zap.Error(err))
 	}
 
-	conn, err := gc.client.GetConnections(ctx)
+	patients, err := gc.provider.Patients(ctx)
 	if err != nil {
-		return
+		gc.logger.Warn("listing patients failed for one or more providers", zap.Error(err))
 	}
 
-	for _, c := range conn {
-		gc.collectGlucose(ctx, ch, c)
+	for _, patient := range patients {
+		gc.collectPatient(ctx, ch, patient)
 	}
 }
 
-func (gc GlucoseCollector) collectGlucose(ctx context.Context, ch chan<- prometheus.Metric, connection librelink.Connection) {
+func (gc GlucoseCollector) collectPatient(ctx context.Context, ch chan<- prometheus.Metric, patient provider.Patient) {
+	reading, err := gc.provider.LatestReading(ctx, patient.ID)
+	if err == nil {
+		ch <- prometheus.NewMetricWithTimestamp(
+			reading.Timestamp,
+			prometheus.MustNewConstMetric(
+				gc.glucoseLevelDesc,
+				prometheus.GaugeValue,
+				reading.MmolPerL,
+				patient.ID,
+				patient.Name,
+				patient.Provider,
+			),
+		)
 
-	data, err := gc.client.GetGraphData(ctx, connection.PatientId)
-	if err != nil {
-		return
+		ch <- prometheus.NewMetricWithTimestamp(
+			reading.Timestamp,
+			prometheus.MustNewConstMetric(
+				gc.glucoseLevelMgdlDesc,
+				prometheus.GaugeValue,
+				reading.MgPerDl,
+				patient.ID,
+				patient.Name,
+				patient.Provider,
+			),
+		)
+
+		ch <- prometheus.NewMetricWithTimestamp(
+			reading.Timestamp,
+			prometheus.MustNewConstMetric(
+				gc.trendDesc,
+				prometheus.GaugeValue,
+				float64(reading.Trend),
+				patient.ID,
+				patient.Name,
+				patient.Provider,
+			),
+		)
 	}
 
-	reading := data.Connection.GlucoseMeasurement
-	if reading == nil {
+	historic, err := gc.provider.HistoricReadings(ctx, patient.ID, time.Now().Add(-historyWindow))
+	if err != nil {
 		return
 	}
 
-	patient_name := fmt.Sprintf("%s %s", connection.FirstName, connection.LastName)
-
-	ch <- prometheus.NewMetricWithTimestamp(
-		reading.Timestamp,
-		prometheus.MustNewConstMetric(
-			gc.glucoseLevelDesc,
-			prometheus.GaugeValue,
-			reading.Value,
-			connection.PatientId,
-			patient_name,
-		),
-	)
-
-	ch <- prometheus.NewMetricWithTimestamp(
-		reading.Timestamp,
-		prometheus.MustNewConstMetric(
-			gc.trendDesc,
-			prometheus.GaugeValue,
-			float64(reading.TrendArrow),
-			connection.PatientId,
-			patient_name,
-		),
-	)
-
-	for _, historic := range data.GraphData {
+	for _, h := range historic {
 		ch <- prometheus.NewMetricWithTimestamp(
-			historic.Timestamp,
+			h.Timestamp,
 			prometheus.MustNewConstMetric(
 				gc.historicDataDesc,
 				prometheus.GaugeValue,
-				historic.Value,
-				connection.PatientId,
-				patient_name,
+				h.MmolPerL,
+				patient.ID,
+				patient.Name,
+				patient.Provider,
+			),
+		)
+
+		ch <- prometheus.NewMetricWithTimestamp(
+			h.Timestamp,
+			prometheus.MustNewConstMetric(
+				gc.historicDataMgdlDesc,
+				prometheus.GaugeValue,
+				h.MgPerDl,
+				patient.ID,
+				patient.Name,
+				patient.Provider,
 			),
 		)
 	}
@@ -0,0 +1,172 @@
+// Package config loads the exporter's runtime configuration from a
+// YAML or JSON file and guards live reloads behind a fingerprinted lock,
+// so a file watcher, a SIGHUP, and (in the future) an admin HTTP PATCH
+// can all safely race to update it.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExporterConfig is the subset of the exporter's configuration that can
+// be loaded from a file and hot-reloaded without restarting the process.
+type ExporterConfig struct {
+	Bind     string `json:"bind" yaml:"bind"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	LogLevel string `json:"log_level" yaml:"log_level"`
+}
+
+// Format selects the serialization used by Marshal/Unmarshal.
+type Format int
+
+const (
+	FormatYAML Format = iota
+	FormatJSON
+)
+
+// FormatFromPath infers a Format from a file's extension, defaulting to
+// YAML for anything that isn't recognized as JSON.
+func FormatFromPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// ConfigHandler guards a live ExporterConfig behind fingerprinted,
+// locked mutations, so a reload can never silently clobber a concurrent
+// change it didn't observe.
+type ConfigHandler interface {
+	Marshal(format Format) ([]byte, error)
+	Unmarshal(format Format, data []byte) error
+	Fingerprint() string
+	DoLockedAction(fingerprint string, callback func(cfg *ExporterConfig) error) error
+}
+
+// Handler is the default ConfigHandler, backed by an RWMutex.
+type Handler struct {
+	mu  sync.RWMutex
+	cfg ExporterConfig
+}
+
+func NewHandler(initial ExporterConfig) *Handler {
+	return &Handler{cfg: initial}
+}
+
+func (h *Handler) Marshal(format Format) ([]byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	switch format {
+	case FormatJSON:
+		return json.Marshal(h.cfg)
+	case FormatYAML:
+		return yaml.Marshal(h.cfg)
+	default:
+		return nil, fmt.Errorf("unknown config format: %d", format)
+	}
+}
+
+func (h *Handler) Unmarshal(format Format, data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch format {
+	case FormatJSON:
+		return json.Unmarshal(data, &h.cfg)
+	case FormatYAML:
+		return yaml.Unmarshal(data, &h.cfg)
+	default:
+		return fmt.Errorf("unknown config format: %d", format)
+	}
+}
+
+// Fingerprint returns a sha256 hex digest of the current serialized
+// config, used as an optimistic-concurrency token for DoLockedAction.
+func (h *Handler) Fingerprint() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.fingerprintLocked()
+}
+
+func (h *Handler) fingerprintLocked() string {
+	raw, _ := json.Marshal(h.cfg)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// DoLockedAction runs callback with exclusive access to the config,
+// rejecting the call if fingerprint does not match the config's current
+// fingerprint (an empty fingerprint skips the check, for the initial
+// load). callback mutates cfg in place.
+func (h *Handler) DoLockedAction(fingerprint string, callback func(cfg *ExporterConfig) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if fingerprint != "" {
+		if current := h.fingerprintLocked(); fingerprint != current {
+			return fmt.Errorf("stale fingerprint %s: config is now at %s", fingerprint, current)
+		}
+	}
+
+	return callback(&h.cfg)
+}
+
+// Current returns a copy of the config as of the last successful load.
+func (h *Handler) Current() ExporterConfig {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.cfg
+}
+
+// LoadFile reads and unmarshals the config file at path into h, inferring
+// the format from its extension.
+func LoadFile(h *Handler, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := h.Unmarshal(FormatFromPath(path), raw); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ReloadFile re-reads the config file at path and atomically replaces h's
+// content, rejecting the reload if fingerprint no longer matches h's
+// current fingerprint (i.e. something else mutated it first).
+func ReloadFile(h *Handler, path string, fingerprint string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	format := FormatFromPath(path)
+	return h.DoLockedAction(fingerprint, func(cfg *ExporterConfig) error {
+		var next ExporterConfig
+		switch format {
+		case FormatJSON:
+			if err := json.Unmarshal(raw, &next); err != nil {
+				return fmt.Errorf("failed to parse config file %s: %w", path, err)
+			}
+		default:
+			if err := yaml.Unmarshal(raw, &next); err != nil {
+				return fmt.Errorf("failed to parse config file %s: %w", path, err)
+			}
+		}
+		*cfg = next
+		return nil
+	})
+}
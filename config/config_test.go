@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_DoLockedAction_EmptyFingerprintSkipsCheck(t *testing.T) {
+	h := NewHandler(ExporterConfig{Username: "alice"})
+
+	err := h.DoLockedAction("", func(cfg *ExporterConfig) error {
+		cfg.Username = "bob"
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "bob", h.Current().Username)
+}
+
+func TestHandler_DoLockedAction_RejectsStaleFingerprint(t *testing.T) {
+	h := NewHandler(ExporterConfig{Username: "alice"})
+	stale := h.Fingerprint()
+
+	// Something else updates the config in between, moving the
+	// fingerprint out from under the stale caller.
+	require.NoError(t, h.DoLockedAction("", func(cfg *ExporterConfig) error {
+		cfg.Username = "bob"
+		return nil
+	}))
+
+	err := h.DoLockedAction(stale, func(cfg *ExporterConfig) error {
+		cfg.Username = "mallory"
+		return nil
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, "bob", h.Current().Username, "callback must not run against a stale fingerprint")
+}
+
+func TestHandler_DoLockedAction_AcceptsCurrentFingerprint(t *testing.T) {
+	h := NewHandler(ExporterConfig{Username: "alice"})
+	current := h.Fingerprint()
+
+	err := h.DoLockedAction(current, func(cfg *ExporterConfig) error {
+		cfg.Username = "bob"
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "bob", h.Current().Username)
+	assert.NotEqual(t, current, h.Fingerprint(), "fingerprint must change once the config does")
+}
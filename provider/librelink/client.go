@@ -0,0 +1,475 @@
+package librelink
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sturaipo/glucose-exporter/retry"
+	"go.uber.org/zap"
+)
+
+func getUrl(region string) string {
+	if region == "" {
+		return "https://api.libreview.io"
+	}
+	return fmt.Sprintf("https://api-%s.libreview.io", region)
+}
+
+type librelinkCreds struct {
+	ticket AuthTicket
+	id     string
+}
+
+func NewLibreLinkCreds(userId string, ticket AuthTicket) *librelinkCreds {
+	hashed := sha256.Sum256([]byte(userId))
+	return &librelinkCreds{
+		ticket: ticket,
+		id:     hex.EncodeToString(hashed[:]),
+	}
+}
+
+// tokenRefreshMargin is how long before a ticket's actual expiry we treat
+// it as already expired, so a re-authentication has room to complete
+// before LibreView would reject the old token.
+const tokenRefreshMargin = 5 * time.Minute
+
+type LibreLinkClient struct {
+	httpClient *http.Client
+	logger     *zap.Logger
+	tokenStore TokenStore
+
+	// mu guards baseUrl, user, password and creds, which are read from
+	// doRequest/ensureAuth on every call and written from Authenticate (a
+	// region redirect or a refreshed ticket), the push-mode goroutine, and
+	// UpdateCredentials on a SIGHUP reload — all of which can run at once.
+	mu       sync.Mutex
+	baseUrl  *url.URL
+	user     string
+	password string
+	creds    *librelinkCreds
+
+	requestTimeout time.Duration
+	maxRetries     int
+	backoffBase    time.Duration
+	backoffMax     time.Duration
+}
+
+func (c *LibreLinkClient) credentials() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.user, c.password
+}
+
+func (c *LibreLinkClient) authTicket() *librelinkCreds {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.creds
+}
+
+func (c *LibreLinkClient) setCreds(creds *librelinkCreds) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds = creds
+}
+
+func (c *LibreLinkClient) baseURL() *url.URL {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.baseUrl
+}
+
+func (c *LibreLinkClient) setBaseURL(u *url.URL) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseUrl = u
+}
+
+func WithCredentials(userId string, token string) func(*LibreLinkClient) {
+	return WithExpiringCredentials(userId, token, time.Time{})
+}
+
+func WithExpiringCredentials(userId string, token string, expiry time.Time) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.creds = NewLibreLinkCreds(
+			userId,
+			AuthTicket{
+				Token:   token,
+				Expires: expiry,
+			},
+		)
+	}
+}
+
+func WithLogger(logger *zap.Logger) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.logger = logger
+	}
+}
+
+// WithTokenStore configures where the client loads and persists its
+// AuthTicket. Without one, tickets only live in memory for the lifetime
+// of the client.
+func WithTokenStore(store TokenStore) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.tokenStore = store
+	}
+}
+
+// WithHTTPTimeout bounds how long a single request attempt may take.
+// It is applied per-attempt, as a context.WithTimeout derived from the
+// caller's ctx, so retries each get a fresh budget.
+func WithHTTPTimeout(d time.Duration) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.requestTimeout = d
+	}
+}
+
+// WithMaxRetries sets how many additional attempts doRequest makes after
+// a retryable failure (a timed-out net.Error, or a 429/5xx response)
+// before giving up.
+func WithMaxRetries(n int) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.maxRetries = n
+	}
+}
+
+// WithRetryBackoff sets the exponential-backoff-with-full-jitter bounds
+// used between retries, unless the server sent a Retry-After header.
+func WithRetryBackoff(base, max time.Duration) func(*LibreLinkClient) {
+	return func(c *LibreLinkClient) {
+		c.backoffBase = base
+		c.backoffMax = max
+	}
+}
+
+func NewLibreLinkClient(user string, password string, options ...func(*LibreLinkClient)) *LibreLinkClient {
+	baseUrl, _ := url.Parse("https://api.libreview.io")
+	client := &LibreLinkClient{
+		baseUrl:    baseUrl,
+		httpClient: &http.Client{},
+
+		user:     user,
+		password: password,
+
+		logger:     zap.NewNop(),
+		tokenStore: NewMemoryTokenStore(),
+
+		requestTimeout: defaultRequestTimeout,
+		maxRetries:     defaultMaxRetries,
+		backoffBase:    defaultBackoffBase,
+		backoffMax:     defaultBackoffMax,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	if client.creds == nil {
+		if stored, ok := client.tokenStore.Load(userKey(user)); ok {
+			client.creds = NewLibreLinkCreds(stored.UserID, stored.Ticket)
+		}
+	}
+
+	return client
+}
+
+// ensureAuth re-authenticates if there are no credentials yet, or the
+// current ticket is within tokenRefreshMargin of expiring, persisting the
+// refreshed ticket back to the configured TokenStore.
+func (c *LibreLinkClient) ensureAuth(ctx context.Context) error {
+	creds := c.authTicket()
+	if creds != nil && (creds.ticket.Expires.IsZero() || time.Now().Before(creds.ticket.Expires.Add(-tokenRefreshMargin))) {
+		return nil
+	}
+
+	return c.Authenticate(ctx)
+}
+
+func (c *LibreLinkClient) handleRedirect(resp LibreLinkResp) (bool, error) {
+	redirect := RedirecResponse{}
+	if err := getPayload(resp, &redirect); err != nil {
+		return false, nil
+	}
+	if !redirect.Redirect {
+		return false, nil
+	}
+
+	if redirect.Region == "" {
+		return false, fmt.Errorf("redirect requested but no region provided")
+	}
+
+	baseUrl, err := url.Parse(getUrl(redirect.Region))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse redirect URL: %w", err)
+	}
+	c.setBaseURL(baseUrl)
+	return true, nil
+}
+
+func (c *LibreLinkClient) prepareRequest(ctx context.Context, method string, endpoint string, body io.Reader) (*http.Request, error) {
+	rel := c.baseURL().JoinPath(endpoint)
+
+	logger := c.logger.With(
+		zap.String("method", method),
+		zap.String("url", rel.String()),
+	)
+
+	logger.Debug("Preparing request")
+
+	req, err := http.NewRequestWithContext(ctx, method, rel.String(), body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("product", "llu.android")
+	req.Header.Set("version", "4.16.0")
+	req.Header.Set("cache-control", "no-cache")
+	req.Header.Set("connection", "Keep-Alive")
+
+	if creds := c.authTicket(); creds != nil {
+		logger.Info("Using existing credentials")
+		req.Header.Set("account-id", creds.id)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", creds.ticket.Token))
+	} else {
+		logger.Info("No credentials available, proceeding without authentication")
+	}
+
+	return req, nil
+}
+
+// doRequest executes one logical LibreLink API call, retrying retryable
+// failures (timed-out net.Errors, 429/5xx responses) with exponential
+// backoff and full jitter, honoring any Retry-After header, and following
+// at most maxRedirects region redirects. body is buffered up front so it
+// can be resent on every attempt.
+func (c *LibreLinkClient) doRequest(ctx context.Context, method string, endpoint string, body io.Reader) (LibreLinkResp, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(body)
+		if err != nil {
+			return LibreLinkResp{}, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	return c.doRequestRedirect(ctx, method, endpoint, bodyBytes, 0)
+}
+
+func (c *LibreLinkClient) doRequestRedirect(ctx context.Context, method string, endpoint string, bodyBytes []byte, redirectDepth int) (LibreLinkResp, error) {
+	if redirectDepth > maxRedirects {
+		return LibreLinkResp{}, fmt.Errorf("too many region redirects (%d)", redirectDepth)
+	}
+
+	logger := c.logger.With(
+		zap.String("method", method),
+		zap.String("url", endpoint),
+	)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retry.Delay(attempt, c.backoffBase, c.backoffMax, retryAfterOf(lastErr))
+			logger.Info("retrying request", zap.Int("attempt", attempt), zap.Duration("delay", delay))
+			if err := retry.Sleep(ctx, delay); err != nil {
+				return LibreLinkResp{}, err
+			}
+		}
+
+		resp, err := c.attemptRequest(ctx, logger, method, endpoint, bodyBytes)
+		if err == nil {
+			redirected, rerr := c.handleRedirect(resp)
+			if rerr != nil {
+				return LibreLinkResp{}, fmt.Errorf("failed to handle redirect: %w", rerr)
+			}
+
+			if redirected {
+				logger.Info("Redirected to new region, retrying request", zap.String("new_base_url", c.baseURL().String()))
+				return c.doRequestRedirect(ctx, method, endpoint, bodyBytes, redirectDepth+1)
+			}
+
+			return resp, nil
+		}
+
+		lastErr = err
+
+		var retryable *RetryableError
+		if !errors.As(err, &retryable) {
+			return LibreLinkResp{}, err
+		}
+	}
+
+	return LibreLinkResp{}, fmt.Errorf("giving up after %d attempts: %w", c.maxRetries+1, lastErr)
+}
+
+// attemptRequest performs a single HTTP round trip. Transient failures
+// (a timed-out net.Error, or a 429/5xx response) are wrapped in a
+// *RetryableError; everything else is permanent.
+func (c *LibreLinkClient) attemptRequest(ctx context.Context, logger *zap.Logger, method string, endpoint string, bodyBytes []byte) (LibreLinkResp, error) {
+	attemptCtx := ctx
+	if c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if bodyBytes != nil {
+		body = bytes.NewReader(bodyBytes)
+	}
+
+	logger.Debug("Preparing to do request")
+	req, err := c.prepareRequest(attemptCtx, method, endpoint, body)
+	if err != nil {
+		return LibreLinkResp{}, fmt.Errorf("failed to prepare request: %w", err)
+	}
+
+	logger.Debug("Executing request")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return LibreLinkResp{}, &RetryableError{Err: fmt.Errorf("request timed out: %w", err)}
+		}
+		return LibreLinkResp{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	logger.Debug("Request completed", zap.Int("status", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+			return LibreLinkResp{}, &RetryableError{
+				Err:        err,
+				RetryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+		return LibreLinkResp{}, err
+	}
+
+	var libreResp LibreLinkResp
+	raw, _ := io.ReadAll(resp.Body)
+	logger.Debug("Response body", zap.ByteString("body", raw))
+
+	if err := json.Unmarshal(raw, &libreResp); err != nil {
+		return LibreLinkResp{}, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	logger.Debug("Response parsed", zap.Int("libre_status", libreResp.Status))
+	if libreResp.Status != 0 {
+		return LibreLinkResp{}, fmt.Errorf("API error %d: %s", libreResp.Status, libreResp.Error.Message)
+	}
+
+	return libreResp, nil
+}
+
+func (c *LibreLinkClient) Authenticate(ctx context.Context) error {
+	endpoint := "llu/auth/login"
+
+	user, password := c.credentials()
+	AuthRequest := AuthRequest{
+		Email:    user,
+		Password: password,
+	}
+
+	reqBody, err := json.Marshal(AuthRequest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+
+	var authResp AuthResponse
+	if err := json.Unmarshal(resp.Data, &authResp); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	c.setCreds(NewLibreLinkCreds(authResp.User.ID, authResp.AuthTicket))
+
+	if c.tokenStore != nil {
+		stored := StoredCredentials{UserID: authResp.User.ID, Ticket: authResp.AuthTicket}
+		if err := c.tokenStore.Save(userKey(user), stored); err != nil {
+			c.logger.Warn("failed to persist auth ticket", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (c *LibreLinkClient) GetConnections(ctx context.Context) ([]Connection, error) {
+	if err := c.ensureAuth(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure authentication: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "llu/connections", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []Connection
+	if err := getPayload(resp, &connections); err != nil {
+		return nil, fmt.Errorf("failed to decode connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+func (c *LibreLinkClient) GetGraphData(ctx context.Context, connectionId string) (GraphData, error) {
+	if err := c.ensureAuth(ctx); err != nil {
+		return GraphData{}, fmt.Errorf("failed to ensure authentication: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("llu/connections/%s/graph", connectionId)
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
+
+	if err != nil {
+		return GraphData{}, err
+	}
+
+	var graphData GraphData
+	if err := getPayload(resp, &graphData); err != nil {
+		return GraphData{}, fmt.Errorf("failed to decode graph data: %w", err)
+	}
+
+	return graphData, nil
+}
+
+func (c *LibreLinkClient) GetLatestReading(ctx context.Context, connectionId string) (GlucoseMeasurement, error) {
+	graphData, err := c.GetGraphData(ctx, connectionId)
+	if err != nil {
+		return GlucoseMeasurement{}, err
+	}
+
+	return *graphData.Connection.GlucoseMeasurement, nil
+}
+
+func (c *LibreLinkClient) IsAuthenticated() bool {
+	return c.authTicket() != nil
+}
+
+// UpdateCredentials replaces the username and password used for future
+// Authenticate calls, e.g. after a config reload rotates a secret. The
+// current AuthTicket, if any, is left untouched until it next expires.
+func (c *LibreLinkClient) UpdateCredentials(user, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.user = user
+	c.password = password
+}
@@ -0,0 +1,19 @@
+package librelink
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequestRedirect_MaxRedirectsExceeded(t *testing.T) {
+	c := NewLibreLinkClient("user", "pass")
+
+	_, err := c.doRequestRedirect(context.Background(), http.MethodGet, "llu/connections", nil, maxRedirects+1)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many region redirects")
+}
@@ -0,0 +1,105 @@
+package librelink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sturaipo/glucose-exporter/provider"
+)
+
+// Provider adapts a LibreLinkClient to provider.CGMProvider, normalizing
+// glucose values to mmol/L + mg/dL and mapping LibreLink's trend arrow
+// onto provider.TrendType.
+type Provider struct {
+	*LibreLinkClient
+}
+
+func NewProvider(client *LibreLinkClient) *Provider {
+	return &Provider{LibreLinkClient: client}
+}
+
+// Authenticate ensures the client holds a non-expiring ticket, logging in
+// only if there isn't one yet or it is about to expire. It shadows the
+// embedded LibreLinkClient.Authenticate, which always forces a fresh login.
+func (p *Provider) Authenticate(ctx context.Context) error {
+	return p.ensureAuth(ctx)
+}
+
+func (p *Provider) Patients(ctx context.Context) ([]provider.Patient, error) {
+	connections, err := p.GetConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	patients := make([]provider.Patient, 0, len(connections))
+	for _, conn := range connections {
+		patients = append(patients, provider.Patient{
+			ID:       conn.PatientId,
+			Name:     fmt.Sprintf("%s %s", conn.FirstName, conn.LastName),
+			Provider: "librelink",
+		})
+	}
+	return patients, nil
+}
+
+func (p *Provider) LatestReading(ctx context.Context, patientID string) (provider.Reading, error) {
+	measurement, err := p.GetLatestReading(ctx, patientID)
+	if err != nil {
+		return provider.Reading{}, err
+	}
+	return toReading(measurement), nil
+}
+
+func (p *Provider) HistoricReadings(ctx context.Context, patientID string, since time.Time) ([]provider.Reading, error) {
+	graphData, err := p.GetGraphData(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	readings := make([]provider.Reading, 0, len(graphData.GraphData))
+	for _, measurement := range graphData.GraphData {
+		if !measurement.Timestamp.After(since) {
+			continue
+		}
+		readings = append(readings, toReading(measurement))
+	}
+	return readings, nil
+}
+
+func trendFromArrow(arrow int) provider.TrendType {
+	switch GlucoseArrow(arrow) {
+	case GlucoseArrowDown:
+		return provider.TrendFalling
+	case GlucoseArrowDownRight:
+		return provider.TrendFallingSlightly
+	case GlucoseArrowRight:
+		return provider.TrendStable
+	case GlucoseArrowUpRight:
+		return provider.TrendRisingSlightly
+	case GlucoseArrowUp:
+		return provider.TrendRising
+	default:
+		return provider.TrendUnknown
+	}
+}
+
+// mmolPerMgdl converts mg/dL to mmol/L.
+const mmolPerMgdl = 1 / 18.0182
+
+func toReading(m GlucoseMeasurement) provider.Reading {
+	mmol := m.Value
+	mgdl := float64(m.ValueInMgPerDl)
+
+	if m.GlucoseUnits == GlucoseUnitsMgPerDl {
+		mgdl = m.Value
+		mmol = m.Value * mmolPerMgdl
+	}
+
+	return provider.Reading{
+		Timestamp: m.Timestamp,
+		MmolPerL:  mmol,
+		MgPerDl:   mgdl,
+		Trend:     trendFromArrow(m.TrendArrow),
+	}
+}
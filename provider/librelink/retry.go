@@ -0,0 +1,35 @@
+package librelink
+
+import (
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultMaxRetries     = 3
+	defaultBackoffBase    = 500 * time.Millisecond
+	defaultBackoffMax     = 10 * time.Second
+
+	maxRedirects = 3
+)
+
+// RetryableError wraps a doRequest failure that is worth retrying (a
+// timed-out net.Error, or a 429/5xx response), as opposed to a permanent
+// failure like a malformed request or a 4xx rejection. Callers and
+// collectors can use errors.As to tell the two apart.
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// retryAfterOf extracts the RetryAfter delay lastErr carried, if any, for
+// retry.Delay to prefer over the backoff schedule.
+func retryAfterOf(lastErr error) time.Duration {
+	if retryable, ok := lastErr.(*RetryableError); ok {
+		return retryable.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package librelink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StoredCredentials is the payload persisted by a TokenStore: the
+// LibreView user id paired with the AuthTicket issued for it.
+type StoredCredentials struct {
+	UserID string     `json:"user_id"`
+	Ticket AuthTicket `json:"ticket"`
+}
+
+// TokenStore persists AuthTickets across process restarts so the client
+// does not have to log in again every time it starts up, which LibreView
+// rate-limits. Entries are keyed by userKey, a hash of the configured
+// username, so a single store can hold more than one account.
+type TokenStore interface {
+	Load(userKey string) (StoredCredentials, bool)
+	Save(userKey string, creds StoredCredentials) error
+}
+
+// userKey derives the TokenStore key for a LibreLink username.
+func userKey(user string) string {
+	hashed := sha256.Sum256([]byte(user))
+	return hex.EncodeToString(hashed[:])
+}
+
+// MemoryTokenStore is a TokenStore that only lives for the duration of
+// the process. It is the default when no store is configured and is
+// useful in tests.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	creds map[string]StoredCredentials
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{creds: make(map[string]StoredCredentials)}
+}
+
+func (s *MemoryTokenStore) Load(userKey string) (StoredCredentials, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	creds, ok := s.creds[userKey]
+	return creds, ok
+}
+
+func (s *MemoryTokenStore) Save(userKey string, creds StoredCredentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creds[userKey] = creds
+	return nil
+}
+
+// FileTokenStore persists tickets for one or more accounts to a single
+// JSON file on disk, keyed by userKey. Writes are atomic (temp file +
+// rename) and the file is created with 0600 permissions since it holds
+// bearer tokens.
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (s *FileTokenStore) Load(userKey string) (StoredCredentials, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return StoredCredentials{}, false
+	}
+
+	creds, ok := all[userKey]
+	return creds, ok
+}
+
+func (s *FileTokenStore) Save(userKey string, creds StoredCredentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		all = make(map[string]StoredCredentials)
+	}
+	all[userKey] = creds
+
+	return s.writeAll(all)
+}
+
+func (s *FileTokenStore) readAll() (map[string]StoredCredentials, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]StoredCredentials)
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, fmt.Errorf("failed to decode token store %s: %w", s.path, err)
+	}
+	return all, nil
+}
+
+func (s *FileTokenStore) writeAll(all map[string]StoredCredentials) error {
+	raw, err := json.Marshal(all)
+	if err != nil {
+		return fmt.Errorf("failed to encode token store: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, ".tokenstore-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename temp file into place: %w", err)
+	}
+
+	return nil
+}
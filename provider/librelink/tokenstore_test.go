@@ -0,0 +1,64 @@
+package librelink
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore_SaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewFileTokenStore(path)
+
+	want := StoredCredentials{
+		UserID: "user-1",
+		Ticket: AuthTicket{Token: "tok-1", Expires: time.Now().Add(time.Hour).Truncate(time.Second)},
+	}
+
+	require.NoError(t, store.Save("key-1", want))
+
+	got, ok := store.Load("key-1")
+	require.True(t, ok)
+	assert.Equal(t, want, got)
+}
+
+func TestFileTokenStore_LoadMissingKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewFileTokenStore(path)
+
+	_, ok := store.Load("missing")
+	assert.False(t, ok)
+}
+
+func TestFileTokenStore_HoldsMultipleAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store := NewFileTokenStore(path)
+
+	first := StoredCredentials{UserID: "user-1", Ticket: AuthTicket{Token: "tok-1"}}
+	second := StoredCredentials{UserID: "user-2", Ticket: AuthTicket{Token: "tok-2"}}
+
+	require.NoError(t, store.Save("key-1", first))
+	require.NoError(t, store.Save("key-2", second))
+
+	got1, ok := store.Load("key-1")
+	require.True(t, ok)
+	assert.Equal(t, first, got1)
+
+	got2, ok := store.Load("key-2")
+	require.True(t, ok)
+	assert.Equal(t, second, got2)
+}
+
+func TestFileTokenStore_PersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	want := StoredCredentials{UserID: "user-1", Ticket: AuthTicket{Token: "tok-1"}}
+
+	require.NoError(t, NewFileTokenStore(path).Save("key-1", want))
+
+	got, ok := NewFileTokenStore(path).Load("key-1")
+	require.True(t, ok)
+	assert.Equal(t, want, got)
+}
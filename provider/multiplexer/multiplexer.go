@@ -0,0 +1,104 @@
+// Package multiplexer fans a single provider.CGMProvider out across
+// several underlying providers, so e.g. a household with a Libre user and
+// a Dexcom (Nightscout) user can be scraped from one exporter.
+package multiplexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sturaipo/glucose-exporter/provider"
+)
+
+// Multiplexer implements provider.CGMProvider by delegating to whichever
+// underlying provider reported a given patient, tracked from the most
+// recent call to Patients.
+type Multiplexer struct {
+	providers []provider.CGMProvider
+
+	mu    sync.RWMutex
+	owner map[string]provider.CGMProvider
+}
+
+func New(providers ...provider.CGMProvider) *Multiplexer {
+	return &Multiplexer{
+		providers: providers,
+		owner:     make(map[string]provider.CGMProvider),
+	}
+}
+
+func (m *Multiplexer) Authenticate(ctx context.Context) error {
+	var errs []error
+	for _, p := range m.providers {
+		if err := p.Authenticate(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+func (m *Multiplexer) Patients(ctx context.Context) ([]provider.Patient, error) {
+	owner := make(map[string]provider.CGMProvider)
+	var all []provider.Patient
+	var errs []error
+
+	for _, p := range m.providers {
+		patients, err := p.Patients(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, patient := range patients {
+			owner[patient.ID] = p
+			all = append(all, patient)
+		}
+	}
+
+	m.mu.Lock()
+	m.owner = owner
+	m.mu.Unlock()
+
+	return all, joinErrors(errs)
+}
+
+func (m *Multiplexer) LatestReading(ctx context.Context, patientID string) (provider.Reading, error) {
+	p, err := m.providerFor(patientID)
+	if err != nil {
+		return provider.Reading{}, err
+	}
+	return p.LatestReading(ctx, patientID)
+}
+
+func (m *Multiplexer) HistoricReadings(ctx context.Context, patientID string, since time.Time) ([]provider.Reading, error) {
+	p, err := m.providerFor(patientID)
+	if err != nil {
+		return nil, err
+	}
+	return p.HistoricReadings(ctx, patientID, since)
+}
+
+func (m *Multiplexer) providerFor(patientID string) (provider.CGMProvider, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.owner[patientID]
+	if !ok {
+		return nil, fmt.Errorf("unknown patient id %q: Patients must be called first", patientID)
+	}
+	return p, nil
+}
+
+func joinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,185 @@
+// Package nightscout implements provider.CGMProvider against a
+// Nightscout instance's /api/v1/entries.json endpoint.
+package nightscout
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sturaipo/glucose-exporter/provider"
+)
+
+// historicEntryCount covers roughly 24h of readings at Nightscout's
+// typical 5-minute SGV cadence.
+const historicEntryCount = 288
+
+type entry struct {
+	Date      int64   `json:"date"`
+	SGV       float64 `json:"sgv"`
+	Direction string  `json:"direction"`
+}
+
+// Client talks to a single Nightscout instance and reports its readings
+// under one configured patient identity, since Nightscout itself has no
+// concept of multiple patients.
+type Client struct {
+	baseUrl *url.URL
+	// apiSecretHash is the sha1 hex digest of the configured API-SECRET:
+	// Nightscout's auth middleware compares the header against the hash
+	// of API_SECRET, not the raw value.
+	apiSecretHash string
+	httpClient    *http.Client
+
+	patientID   string
+	patientName string
+}
+
+func WithHTTPClient(httpClient *http.Client) func(*Client) {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithPatient overrides the id and display name reported for the single
+// patient this Nightscout instance represents. Defaults to "nightscout".
+func WithPatient(id, name string) func(*Client) {
+	return func(c *Client) {
+		c.patientID = id
+		c.patientName = name
+	}
+}
+
+func NewClient(baseURL string, apiSecret string, options ...func(*Client)) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nightscout base url: %w", err)
+	}
+
+	hashed := sha1.Sum([]byte(apiSecret))
+
+	c := &Client{
+		baseUrl:       parsed,
+		apiSecretHash: hex.EncodeToString(hashed[:]),
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+
+		patientID:   "nightscout",
+		patientName: "Nightscout",
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
+
+// Authenticate is a no-op: Nightscout authorizes each request with the
+// API-SECRET header rather than a separate login call.
+func (c *Client) Authenticate(ctx context.Context) error {
+	return nil
+}
+
+func (c *Client) Patients(ctx context.Context) ([]provider.Patient, error) {
+	return []provider.Patient{
+		{ID: c.patientID, Name: c.patientName, Provider: "nightscout"},
+	}, nil
+}
+
+func (c *Client) LatestReading(ctx context.Context, patientID string) (provider.Reading, error) {
+	entries, err := c.entries(ctx, 1)
+	if err != nil {
+		return provider.Reading{}, err
+	}
+	if len(entries) == 0 {
+		return provider.Reading{}, fmt.Errorf("nightscout returned no entries")
+	}
+	return toReading(entries[0]), nil
+}
+
+func (c *Client) HistoricReadings(ctx context.Context, patientID string, since time.Time) ([]provider.Reading, error) {
+	entries, err := c.entries(ctx, historicEntryCount)
+	if err != nil {
+		return nil, err
+	}
+
+	readings := make([]provider.Reading, 0, len(entries))
+	for _, e := range entries {
+		reading := toReading(e)
+		if reading.Timestamp.After(since) {
+			readings = append(readings, reading)
+		}
+	}
+	return readings, nil
+}
+
+func (c *Client) entries(ctx context.Context, count int) ([]entry, error) {
+	rel := c.baseUrl.JoinPath("api/v1/entries.json")
+
+	query := rel.Query()
+	query.Set("count", fmt.Sprintf("%d", count))
+	rel.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rel.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("API-SECRET", c.apiSecretHash)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var entries []entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+const mmolPerMgdl = 1 / 18.0182
+
+func toReading(e entry) provider.Reading {
+	return provider.Reading{
+		Timestamp: time.UnixMilli(e.Date),
+		MgPerDl:   e.SGV,
+		MmolPerL:  e.SGV * mmolPerMgdl,
+		Trend:     trendFromDirection(e.Direction),
+	}
+}
+
+func trendFromDirection(direction string) provider.TrendType {
+	switch direction {
+	case "DoubleDown", "SingleDown":
+		return provider.TrendFalling
+	case "FortyFiveDown":
+		return provider.TrendFallingSlightly
+	case "Flat":
+		return provider.TrendStable
+	case "FortyFiveUp":
+		return provider.TrendRisingSlightly
+	case "SingleUp", "DoubleUp":
+		return provider.TrendRising
+	default:
+		return provider.TrendUnknown
+	}
+}
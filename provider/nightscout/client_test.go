@@ -0,0 +1,34 @@
+package nightscout
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SendsHashedAPISecret(t *testing.T) {
+	const secret = "super-secret"
+	want := sha1.Sum([]byte(secret))
+
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("API-SECRET")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, secret)
+	require.NoError(t, err)
+
+	_, err = c.LatestReading(context.Background(), "nightscout")
+	require.Error(t, err, "an empty entries response is reported as an error")
+
+	assert.Equal(t, hex.EncodeToString(want[:]), gotHeader)
+}
@@ -0,0 +1,53 @@
+// Package provider defines the interface the collector uses to talk to a
+// CGM data source, so it isn't hardcoded to any single vendor.
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// TrendType is a vendor-agnostic glucose trend direction.
+type TrendType int
+
+const (
+	TrendUnknown TrendType = iota
+	TrendFalling
+	TrendFallingSlightly
+	TrendStable
+	TrendRisingSlightly
+	TrendRising
+)
+
+// Patient identifies one person whose readings a CGMProvider can report.
+// Provider names the implementation that owns this patient (e.g.
+// "librelink", "nightscout"), which lets a multiplexer route calls back
+// to the right underlying provider.
+type Patient struct {
+	ID       string
+	Name     string
+	Provider string
+}
+
+// Reading is a single glucose measurement normalized across providers.
+type Reading struct {
+	Timestamp time.Time
+	MmolPerL  float64
+	MgPerDl   float64
+	Trend     TrendType
+}
+
+// CGMProvider is implemented by each supported CGM data source (LibreLink,
+// Nightscout, ...) and by the multiplexer that fans out across several of
+// them.
+type CGMProvider interface {
+	// Authenticate ensures the provider is ready to serve requests. It is
+	// expected to be idempotent and cheap to call repeatedly (e.g. once
+	// per scrape) — implementations should only do real work the first
+	// time, or when a cached credential is about to expire.
+	Authenticate(ctx context.Context) error
+
+	Patients(ctx context.Context) ([]Patient, error)
+	LatestReading(ctx context.Context, patientID string) (Reading, error)
+	HistoricReadings(ctx context.Context, patientID string, since time.Time) ([]Reading, error)
+}
@@ -0,0 +1,362 @@
+package pusher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/sturaipo/glucose-exporter/provider/librelink"
+	"github.com/sturaipo/glucose-exporter/retry"
+	"go.uber.org/zap"
+)
+
+const (
+	metricCurrentLevel  = "glucose_level_mmoll"
+	metricHistoricLevel = "glucose_historic_level"
+
+	defaultBackoffBase = 1 * time.Second
+	defaultBackoffMax  = 30 * time.Second
+)
+
+// Endpoint describes a single Prometheus remote_write target and its
+// optional authentication. Only one of BearerToken or BasicUser/BasicPass
+// should be set; BearerToken takes precedence if both are.
+type Endpoint struct {
+	URL         string
+	BearerToken string
+	BasicUser   string
+	BasicPass   string
+}
+
+func (e Endpoint) authorize(req *http.Request) {
+	if e.BearerToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.BearerToken))
+		return
+	}
+	if e.BasicUser != "" {
+		req.SetBasicAuth(e.BasicUser, e.BasicPass)
+	}
+}
+
+// Pusher periodically polls a LibreLinkClient and pushes the current and
+// historic glucose samples to one or more Prometheus remote_write
+// endpoints, deduplicating historic points that were already sent.
+type Pusher struct {
+	client    *librelink.LibreLinkClient
+	endpoints []Endpoint
+
+	httpClient *http.Client
+	logger     *zap.Logger
+
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+
+	mu sync.Mutex
+	// sent tracks, per endpoint and patient, the timestamp of the newest
+	// historic sample successfully pushed so far. It is keyed per endpoint
+	// because one endpoint can be unreachable while another isn't, and a
+	// batch that failed to push must not be dropped from the next retry.
+	sent map[sentKey]time.Time
+}
+
+type sentKey struct {
+	endpoint  string
+	patientID string
+}
+
+// patientSamples is one patient's connection metadata plus the graph data
+// fetched for them on this poll, kept around so it can be turned into a
+// series independently for each endpoint.
+type patientSamples struct {
+	conn      librelink.Connection
+	graphData librelink.GraphData
+}
+
+func WithLogger(logger *zap.Logger) func(*Pusher) {
+	return func(p *Pusher) {
+		p.logger = logger
+	}
+}
+
+func WithHTTPClient(client *http.Client) func(*Pusher) {
+	return func(p *Pusher) {
+		p.httpClient = client
+	}
+}
+
+func WithMaxRetries(n int) func(*Pusher) {
+	return func(p *Pusher) {
+		p.maxRetries = n
+	}
+}
+
+func WithRetryBackoff(base, max time.Duration) func(*Pusher) {
+	return func(p *Pusher) {
+		p.backoffBase = base
+		p.backoffMax = max
+	}
+}
+
+func NewPusher(client *librelink.LibreLinkClient, endpoints []Endpoint, options ...func(*Pusher)) *Pusher {
+	p := &Pusher{
+		client:      client,
+		endpoints:   endpoints,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		logger:      zap.NewNop(),
+		maxRetries:  5,
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+		sent:        make(map[sentKey]time.Time),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// Run polls the configured LibreLinkClient on the given interval and
+// pushes the resulting samples until ctx is cancelled.
+func (p *Pusher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.pushOnce(ctx); err != nil {
+			p.logger.Error("push cycle failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pusher) pushOnce(ctx context.Context) error {
+	if !p.client.IsAuthenticated() {
+		if err := p.client.Authenticate(ctx); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	connections, err := p.client.GetConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	samples := make([]patientSamples, 0, len(connections))
+	for _, conn := range connections {
+		graphData, err := p.client.GetGraphData(ctx, conn.PatientId)
+		if err != nil {
+			p.logger.Warn("failed to collect samples for patient", zap.String("patient_id", conn.PatientId), zap.Error(err))
+			continue
+		}
+		samples = append(samples, patientSamples{conn: conn, graphData: graphData})
+	}
+
+	for _, endpoint := range p.endpoints {
+		if err := p.pushToEndpoint(ctx, endpoint, samples); err != nil {
+			p.logger.Error("failed to push samples", zap.String("endpoint", endpoint.URL), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// pushToEndpoint builds this endpoint's series from samples, pushes it,
+// and only then advances the per-endpoint dedup watermark — so a batch
+// that fails to push (this endpoint down, request timed out, ...) is
+// retried on the next poll instead of being dropped.
+func (p *Pusher) pushToEndpoint(ctx context.Context, endpoint Endpoint, samples []patientSamples) error {
+	series, watermarks := p.seriesFor(endpoint, samples)
+	if len(series) == 0 {
+		return nil
+	}
+
+	if err := p.push(ctx, endpoint, series); err != nil {
+		return err
+	}
+
+	for patientID, newest := range watermarks {
+		p.setLastSent(endpoint.URL, patientID, newest)
+	}
+
+	return nil
+}
+
+// seriesFor builds the TimeSeries to push to endpoint, deduplicating
+// historic points against that endpoint's own watermark, and returns the
+// newest historic timestamp seen per patient so the caller can advance
+// the watermark once the push actually succeeds.
+func (p *Pusher) seriesFor(endpoint Endpoint, samples []patientSamples) ([]prompb.TimeSeries, map[string]time.Time) {
+	var series []prompb.TimeSeries
+	watermarks := make(map[string]time.Time)
+
+	for _, s := range samples {
+		patientName := fmt.Sprintf("%s %s", s.conn.FirstName, s.conn.LastName)
+		baseLabels := []prompb.Label{
+			{Name: "patient_id", Value: s.conn.PatientId},
+			{Name: "patient_name", Value: patientName},
+		}
+
+		if reading := s.graphData.Connection.GlucoseMeasurement; reading != nil {
+			series = append(series, newTimeSeries(metricCurrentLevel, baseLabels, reading.Timestamp, reading.Value))
+		}
+
+		since := p.lastSent(endpoint.URL, s.conn.PatientId)
+		newest := since
+
+		for _, historic := range s.graphData.GraphData {
+			if !historic.Timestamp.After(since) {
+				continue
+			}
+			series = append(series, newTimeSeries(metricHistoricLevel, baseLabels, historic.Timestamp, historic.Value))
+			if historic.Timestamp.After(newest) {
+				newest = historic.Timestamp
+			}
+		}
+
+		if newest.After(since) {
+			watermarks[s.conn.PatientId] = newest
+		}
+	}
+
+	return series, watermarks
+}
+
+func (p *Pusher) lastSent(endpoint, patientID string) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sent[sentKey{endpoint: endpoint, patientID: patientID}]
+}
+
+func (p *Pusher) setLastSent(endpoint, patientID string, ts time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent[sentKey{endpoint: endpoint, patientID: patientID}] = ts
+}
+
+func newTimeSeries(name string, baseLabels []prompb.Label, ts time.Time, value float64) prompb.TimeSeries {
+	labels := append([]prompb.Label{{Name: "__name__", Value: name}}, baseLabels...)
+	return prompb.TimeSeries{
+		Labels: labels,
+		Samples: []prompb.Sample{
+			{Value: value, Timestamp: ts.UnixMilli()},
+		},
+	}
+}
+
+func (p *Pusher) push(ctx context.Context, endpoint Endpoint, series []prompb.TimeSeries) error {
+	payload, err := (&prompb.WriteRequest{Timeseries: series}).Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal write request: %w", err)
+	}
+	compressed := snappy.Encode(nil, payload)
+
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retry.Delay(attempt, p.backoffBase, p.backoffMax, retryAfterOf(lastErr))
+			if err := retry.Sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		retryAfter, err := p.attemptPush(ctx, endpoint, compressed)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if retryAfter > 0 {
+			lastErr = retryAfterError{delay: retryAfter, err: err}
+		}
+
+		var perr *PermanentError
+		if isPermanent(err, &perr) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", p.maxRetries+1, lastErr)
+}
+
+func (p *Pusher) attemptPush(ctx context.Context, endpoint Endpoint, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, &PermanentError{Err: fmt.Errorf("failed to create request: %w", err)}
+	}
+
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	endpoint.authorize(req)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return 0, nil
+	}
+
+	retryAfter := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+	err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return retryAfter, err
+	}
+
+	return 0, &PermanentError{Err: err}
+}
+
+// PermanentError marks a push failure that a retry would not fix (e.g. a
+// rejected request body or an auth failure on the remote_write endpoint).
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+func isPermanent(err error, target **PermanentError) bool {
+	perr, ok := err.(*PermanentError)
+	if !ok {
+		if rerr, ok := err.(retryAfterError); ok {
+			_, ok = rerr.err.(*PermanentError)
+			return ok
+		}
+		return false
+	}
+	*target = perr
+	return true
+}
+
+type retryAfterError struct {
+	delay time.Duration
+	err   error
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+func (e retryAfterError) Unwrap() error { return e.err }
+
+// retryAfterOf extracts the Retry-After delay lastErr carried, if any, for
+// retry.Delay to prefer over the backoff schedule.
+func retryAfterOf(lastErr error) time.Duration {
+	if rerr, ok := lastErr.(retryAfterError); ok {
+		return rerr.delay
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package pusher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sturaipo/glucose-exporter/provider/librelink"
+)
+
+func samplesFor(patientID string, historicAt time.Time) []patientSamples {
+	return []patientSamples{
+		{
+			conn: librelink.Connection{PatientId: patientID, FirstName: "Jane", LastName: "Doe"},
+			graphData: librelink.GraphData{
+				GraphData: []librelink.GlucoseMeasurement{
+					{Timestamp: historicAt, Value: 5.5},
+				},
+			},
+		},
+	}
+}
+
+func TestPushToEndpoint_WatermarkDoesNotAdvanceOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p := NewPusher(nil, nil, WithMaxRetries(0))
+	endpoint := Endpoint{URL: server.URL}
+	historicAt := time.Now().Add(-time.Hour)
+
+	err := p.pushToEndpoint(context.Background(), endpoint, samplesFor("patient-1", historicAt))
+
+	require.Error(t, err)
+	assert.True(t, p.lastSent(endpoint.URL, "patient-1").IsZero(), "watermark must not advance when push failed")
+}
+
+func TestPushToEndpoint_WatermarkAdvancesOnSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := NewPusher(nil, nil, WithMaxRetries(0))
+	endpoint := Endpoint{URL: server.URL}
+	historicAt := time.Now().Add(-time.Hour)
+
+	err := p.pushToEndpoint(context.Background(), endpoint, samplesFor("patient-1", historicAt))
+
+	require.NoError(t, err)
+	assert.WithinDuration(t, historicAt, p.lastSent(endpoint.URL, "patient-1"), time.Millisecond)
+}
+
+func TestPushToEndpoint_WatermarkIsPerEndpoint(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer up.Close()
+
+	p := NewPusher(nil, nil, WithMaxRetries(0))
+	historicAt := time.Now().Add(-time.Hour)
+	samples := samplesFor("patient-1", historicAt)
+
+	require.Error(t, p.pushToEndpoint(context.Background(), Endpoint{URL: down.URL}, samples))
+	require.NoError(t, p.pushToEndpoint(context.Background(), Endpoint{URL: up.URL}, samples))
+
+	assert.True(t, p.lastSent(down.URL, "patient-1").IsZero(), "the down endpoint's watermark must stay unset")
+	assert.WithinDuration(t, historicAt, p.lastSent(up.URL, "patient-1"), time.Millisecond, "the healthy endpoint's watermark must advance")
+}
@@ -0,0 +1,66 @@
+// Package retry holds the exponential-backoff-with-full-jitter and
+// Retry-After handling shared by every HTTP client in this exporter that
+// retries transient failures (provider/librelink and pusher).
+package retry
+
+import (
+	"context"
+	"crypto/rand"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Delay computes how long to wait before the next attempt: retryAfter if
+// it is positive (a server-specified Retry-After), otherwise exponential
+// backoff with full jitter (rand(0, min(max, base*2^attempt))).
+func Delay(attempt int, base, max, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	capped := math.Min(float64(max), float64(base)*math.Pow(2, float64(attempt)))
+	if capped <= 0 {
+		return 0
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(capped)+1))
+	if err != nil {
+		return base
+	}
+	return time.Duration(n.Int64())
+}
+
+// ParseRetryAfter parses a Retry-After header value, which per RFC 7231
+// is either a number of seconds or an HTTP-date. It returns 0 if header
+// is empty or doesn't parse as either.
+func ParseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// Sleep waits for d, returning ctx.Err() early if ctx is done first. A
+// non-positive d returns immediately.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
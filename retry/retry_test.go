@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay_PrefersRetryAfter(t *testing.T) {
+	d := Delay(3, time.Second, 30*time.Second, 7*time.Second)
+	assert.Equal(t, 7*time.Second, d)
+}
+
+func TestDelay_FullJitterBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := time.Second
+
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{name: "attempt 0", attempt: 0, want: base},
+		{name: "attempt 2", attempt: 2, want: 400 * time.Millisecond},
+		{name: "attempt saturates at max", attempt: 10, want: max},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				d := Delay(tt.attempt, base, max, 0)
+				assert.GreaterOrEqual(t, d, time.Duration(0), "delay must not be negative")
+				assert.LessOrEqual(t, d, tt.want, "delay must not exceed the capped backoff")
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "empty header", header: "", want: 0},
+		{name: "seconds", header: "5", want: 5 * time.Second},
+		{name: "unparseable", header: "not-a-date", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseRetryAfter(tt.header))
+		})
+	}
+}
+
+func TestSleep_ReturnsEarlyOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Sleep(ctx, time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSleep_ZeroDelayIsNoop(t *testing.T) {
+	require.NoError(t, Sleep(context.Background(), 0))
+}